@@ -0,0 +1,130 @@
+// Package merkle builds a binary Merkle tree over a block's transactions so
+// a light client can verify a single transaction was committed by a block
+// without downloading the whole block body — it only needs the block's
+// TxRoot (already part of the header it trusts) plus a short inclusion
+// proof.
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Tree is a SHA-256 Merkle tree over an ordered list of leaves, with the
+// last leaf duplicated as needed to pad the leaf count to a power of two.
+type Tree struct {
+	leaves [][]byte   // original, unpadded leaf hashes
+	levels [][][]byte // levels[0] is the padded leaf level, levels[len-1] is the root
+}
+
+// BuildTree hashes each entry in leaves and assembles the tree. leaves must
+// be in the same order the corresponding transactions appear in the block.
+func BuildTree(leaves [][]byte) *Tree {
+	hashed := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		hashed[i] = leafHash(leaf)
+	}
+
+	level := padToPowerOfTwo(hashed)
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		level = parentLevel(level)
+		levels = append(levels, level)
+	}
+
+	return &Tree{leaves: hashed, levels: levels}
+}
+
+// Root returns the Merkle root, or nil for an empty tree.
+func (t *Tree) Root() []byte {
+	if len(t.levels) == 0 {
+		return nil
+	}
+	top := t.levels[len(t.levels)-1]
+	if len(top) == 0 {
+		return nil
+	}
+	return top[0]
+}
+
+// Proof returns the sibling hashes needed to recompute the root from the
+// leaf at index, ordered from the leaf level up to the root.
+func (t *Tree) Proof(index int) ([][]byte, error) {
+	if index < 0 || index >= len(t.leaves) {
+		return nil, fmt.Errorf("merkle: index %d out of range for %d leaves", index, len(t.leaves))
+	}
+
+	var proof [][]byte
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			proof = append(proof, level[sibling])
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// VerifyProof recomputes the root from leaf using proof and index, and
+// reports whether it matches root.
+func VerifyProof(root []byte, leaf []byte, proof [][]byte, index int) bool {
+	hash := leafHash(leaf)
+	for _, sibling := range proof {
+		if index%2 == 0 {
+			hash = parentHash(hash, sibling)
+		} else {
+			hash = parentHash(sibling, hash)
+		}
+		index /= 2
+	}
+	return bytes.Equal(hash, root)
+}
+
+// leafPrefix and parentPrefix domain-separate leaf hashes from internal-node
+// hashes, RFC 6962-style: without them a 64-byte transaction could be crafted
+// to collide with some internal node's own (left||right) hash, letting a
+// forged leaf masquerade as a subtree the root already committed to.
+const (
+	leafPrefix   = 0x00
+	parentPrefix = 0x01
+)
+
+func leafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	sum := h.Sum(nil)
+	return sum
+}
+
+func parentHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{parentPrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+func padToPowerOfTwo(leaves [][]byte) [][]byte {
+	if len(leaves) == 0 {
+		return leaves
+	}
+	padded := append([][]byte{}, leaves...)
+	for !isPowerOfTwo(len(padded)) {
+		padded = append(padded, padded[len(padded)-1])
+	}
+	return padded
+}
+
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
+
+func parentLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, len(level)/2)
+	for i := 0; i < len(level); i += 2 {
+		next = append(next, parentHash(level[i], level[i+1]))
+	}
+	return next
+}
@@ -0,0 +1,60 @@
+// Package beacon provides sources of verifiable public randomness used to
+// seed epoch-based slot-leader elections.
+//
+// Historically BDNS derived its per-epoch seed from a commit-reveal exchange
+// between registries (see consensus.CommitmentPhase). That scheme requires
+// liveness from every participating registry, offers no way for an outside
+// observer to check the result, and is biasable by whichever registry reveals
+// last. BeaconAPI abstracts the seed source so that scheme can live on as a
+// fallback (LocalBeacon) while production deployments pull verifiable
+// randomness from an external beacon such as drand (DrandBeacon).
+package beacon
+
+import "context"
+
+// BeaconEntry is a single round of randomness produced by a beacon chain.
+type BeaconEntry struct {
+	Round      uint64
+	Randomness []byte
+	Signature  []byte
+}
+
+// BeaconAPI is implemented by any source of verifiable public randomness
+// that can be used to seed an epoch's slot-leader election.
+type BeaconAPI interface {
+	// Entry returns the beacon entry for the given round, or an error if that
+	// round cannot be obtained at the time of the call; it does not wait for
+	// a round that is not available yet.
+	Entry(ctx context.Context, round uint64) (BeaconEntry, error)
+
+	// VerifyEntry checks that cur is a valid successor of prev in this
+	// beacon's chain (e.g. a correctly chained drand signature).
+	VerifyEntry(prev, cur BeaconEntry) error
+
+	// LatestBeaconRound returns the highest round this beacon has observed.
+	LatestBeaconRound() uint64
+}
+
+// BeaconChainInfo identifies the beacon chain a node should watch and how its
+// rounds line up with wall-clock time, mirroring the `/info` response of a
+// drand chain.
+type BeaconChainInfo struct {
+	ChainHash   string // hex-encoded hash identifying the chain being followed
+	PublicKey   []byte // group public key used to verify entry signatures
+	GenesisTime int64  // unix seconds of round 1
+	Period      int64  // seconds between rounds
+}
+
+// RoundAt returns the beacon round that covers the given epoch, derived from
+// the chain's genesis time and period and the node's epoch interval.
+func (c BeaconChainInfo) RoundAt(epoch, epochInterval, initialTimestamp int64) uint64 {
+	if c.Period <= 0 {
+		return 0
+	}
+	epochTime := initialTimestamp + epoch*epochInterval
+	elapsed := epochTime - c.GenesisTime
+	if elapsed < 0 {
+		return 1
+	}
+	return uint64(elapsed/c.Period) + 1
+}
@@ -0,0 +1,210 @@
+package beacon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	bls12381 "github.com/drand/kyber-bls12381"
+	"github.com/drand/kyber/sign/bls"
+)
+
+// drandSuite is deliberately separate from beacon/vrf.go's bn256 vrfSuite:
+// the League-of-Entropy chains this package talks to (see ConfigureBeacon)
+// sign on BLS12-381, not bn256, so a node's own VRF keys and a drand chain's
+// group key are never interchangeable and must be verified with their own
+// curve's suite.
+var drandSuite = bls12381.NewBLS12381Suite()
+
+// DrandBeacon watches a drand League-of-Entropy-style HTTP chain endpoint,
+// caching observed entries by round and fanning new ones out over Entries.
+type DrandBeacon struct {
+	ChainInfo BeaconChainInfo
+	Endpoint  string // base URL of the drand HTTP relay, e.g. "https://api.drand.sh"
+
+	Entries chan BeaconEntry
+
+	mu     sync.Mutex
+	cache  map[uint64]BeaconEntry
+	latest uint64
+	client *http.Client
+	cancel context.CancelFunc
+}
+
+// NewDrandBeacon constructs a DrandBeacon for the given chain and starts
+// polling the relay for new rounds.
+func NewDrandBeacon(ctx context.Context, info BeaconChainInfo, endpoint string) *DrandBeacon {
+	ctx, cancel := context.WithCancel(ctx)
+	b := &DrandBeacon{
+		ChainInfo: info,
+		Endpoint:  endpoint,
+		Entries:   make(chan BeaconEntry, 16),
+		cache:     make(map[uint64]BeaconEntry),
+		client:    &http.Client{Timeout: 10 * time.Second},
+		cancel:    cancel,
+	}
+	go b.watch(ctx)
+	return b
+}
+
+// Close stops the background watch loop.
+func (b *DrandBeacon) Close() {
+	b.cancel()
+}
+
+func (b *DrandBeacon) watch(ctx context.Context) {
+	ticker := time.NewTicker(b.period())
+	defer ticker.Stop()
+
+	for {
+		if entry, err := b.fetch(ctx, 0); err == nil {
+			b.store(entry)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *DrandBeacon) period() time.Duration {
+	if b.ChainInfo.Period <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(b.ChainInfo.Period) * time.Second
+}
+
+// drandRoundResponse mirrors the JSON body returned by a drand relay's
+// `/{chainHash}/public/{round}` endpoint.
+type drandRoundResponse struct {
+	Round      uint64 `json:"round"`
+	Randomness string `json:"randomness"`
+	Signature  string `json:"signature"`
+}
+
+func (b *DrandBeacon) fetch(ctx context.Context, round uint64) (BeaconEntry, error) {
+	path := "latest"
+	if round != 0 {
+		path = fmt.Sprintf("%d", round)
+	}
+	url := fmt.Sprintf("%s/%s/public/%s", b.Endpoint, b.ChainInfo.ChainHash, path)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return BeaconEntry{}, err
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return BeaconEntry{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return BeaconEntry{}, fmt.Errorf("beacon: drand relay returned %s", resp.Status)
+	}
+
+	var body drandRoundResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return BeaconEntry{}, err
+	}
+
+	randomness, err := decodeHex(body.Randomness)
+	if err != nil {
+		return BeaconEntry{}, err
+	}
+	signature, err := decodeHex(body.Signature)
+	if err != nil {
+		return BeaconEntry{}, err
+	}
+
+	return BeaconEntry{Round: body.Round, Randomness: randomness, Signature: signature}, nil
+}
+
+func (b *DrandBeacon) store(entry BeaconEntry) {
+	b.mu.Lock()
+	_, known := b.cache[entry.Round]
+	b.cache[entry.Round] = entry
+	if entry.Round > b.latest {
+		b.latest = entry.Round
+	}
+	b.mu.Unlock()
+
+	if !known {
+		select {
+		case b.Entries <- entry:
+		default:
+		}
+	}
+}
+
+// Entry implements BeaconAPI, fetching the round from the relay on a cache
+// miss.
+func (b *DrandBeacon) Entry(ctx context.Context, round uint64) (BeaconEntry, error) {
+	b.mu.Lock()
+	entry, ok := b.cache[round]
+	b.mu.Unlock()
+	if ok {
+		return entry, nil
+	}
+
+	entry, err := b.fetch(ctx, round)
+	if err != nil {
+		return BeaconEntry{}, err
+	}
+	b.store(entry)
+	return entry, nil
+}
+
+// VerifyEntry checks that cur.Signature verifies against the chain's group
+// public key over (prev.Signature, cur.Round), per the drand chained-beacon
+// scheme.
+func (b *DrandBeacon) VerifyEntry(prev, cur BeaconEntry) error {
+	if cur.Round != prev.Round+1 {
+		return fmt.Errorf("beacon: round %d does not chain from %d", cur.Round, prev.Round)
+	}
+	if len(cur.Signature) == 0 {
+		return fmt.Errorf("beacon: entry for round %d has no signature", cur.Round)
+	}
+
+	pubKey := drandSuite.G2().Point()
+	if err := pubKey.UnmarshalBinary(b.ChainInfo.PublicKey); err != nil {
+		return fmt.Errorf("beacon: invalid chain public key: %w", err)
+	}
+
+	msg := chainedBeaconMessage(prev.Signature, cur.Round)
+	if err := bls.Verify(drandSuite, pubKey, msg, cur.Signature); err != nil {
+		return fmt.Errorf("beacon: signature for round %d does not verify against chain public key: %w", cur.Round, err)
+	}
+	return nil
+}
+
+// chainedBeaconMessage reproduces the message a drand chained-beacon round
+// signs: SHA-256(prevSignature || round), big-endian.
+func chainedBeaconMessage(prevSignature []byte, round uint64) []byte {
+	h := sha256.New()
+	h.Write(prevSignature)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], round)
+	h.Write(buf[:])
+	return h.Sum(nil)
+}
+
+// LatestBeaconRound implements BeaconAPI.
+func (b *DrandBeacon) LatestBeaconRound() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.latest
+}
+
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(s)
+}
@@ -0,0 +1,13 @@
+package beacon
+
+// ElectionProof is the VRF evidence a node attaches to a block proposal for
+// an epoch, carried inside the block itself so any peer can independently
+// check the proposer won slot leadership instead of trusting a shared
+// SlotLeaders map. It lives in this package (rather than network, where it
+// was first defined) so blockchain.Block can reference it without importing
+// the network package.
+type ElectionProof struct {
+	Epoch  int64
+	Output []byte
+	Proof  []byte
+}
@@ -0,0 +1,97 @@
+package beacon
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bleasey/bdns/internal/consensus"
+)
+
+// LocalBeacon implements BeaconAPI on top of the original commit-reveal
+// exchange between registries. It exists so tests and offline single-node
+// runs keep working without a drand relay reachable; it offers none of
+// DrandBeacon's external verifiability.
+type LocalBeacon struct {
+	mu      sync.Mutex
+	rounds  map[uint64]map[string]consensus.SecretValues
+	entries map[uint64]BeaconEntry
+	latest  uint64
+}
+
+// NewLocalBeacon constructs an empty LocalBeacon.
+func NewLocalBeacon() *LocalBeacon {
+	return &LocalBeacon{
+		rounds:  make(map[uint64]map[string]consensus.SecretValues),
+		entries: make(map[uint64]BeaconEntry),
+	}
+}
+
+// SubmitSecret records a registry's commit-reveal contribution for a round,
+// finalizing the round's BeaconEntry once contributions from as many
+// distinct senders as registryKeys has entries have been recorded. Senders
+// are not checked against registryKeys.
+func (b *LocalBeacon) SubmitSecret(round uint64, sender string, value consensus.SecretValues, registryKeys [][]byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rounds[round] == nil {
+		b.rounds[round] = make(map[string]consensus.SecretValues)
+	}
+	b.rounds[round][sender] = value
+
+	if len(b.rounds[round]) < len(registryKeys) {
+		return
+	}
+
+	b.finalize(round)
+}
+
+func (b *LocalBeacon) finalize(round uint64) {
+	senders := make([]string, 0, len(b.rounds[round]))
+	for sender := range b.rounds[round] {
+		senders = append(senders, sender)
+	}
+	sort.Strings(senders)
+
+	h := sha256.New()
+	for _, sender := range senders {
+		v := b.rounds[round][sender]
+		fmt.Fprintf(h, "%s:%d:%d", sender, v.SecretValue, v.RandomValue)
+	}
+
+	b.entries[round] = BeaconEntry{Round: round, Randomness: h.Sum(nil)}
+	if round > b.latest {
+		b.latest = round
+	}
+}
+
+// Entry implements BeaconAPI.
+func (b *LocalBeacon) Entry(ctx context.Context, round uint64) (BeaconEntry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.entries[round]
+	if !ok {
+		return BeaconEntry{}, fmt.Errorf("beacon: round %d has not finished commit-reveal", round)
+	}
+	return entry, nil
+}
+
+// VerifyEntry implements BeaconAPI. LocalBeacon has no external signature to
+// check; it only enforces that rounds are presented in order.
+func (b *LocalBeacon) VerifyEntry(prev, cur BeaconEntry) error {
+	if cur.Round != prev.Round+1 {
+		return fmt.Errorf("beacon: round %d does not follow %d", cur.Round, prev.Round)
+	}
+	return nil
+}
+
+// LatestBeaconRound implements BeaconAPI.
+func (b *LocalBeacon) LatestBeaconRound() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.latest
+}
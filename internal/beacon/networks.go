@@ -0,0 +1,31 @@
+package beacon
+
+import "sort"
+
+// BeaconNetworks is an ordered list of beacons keyed by the round at which
+// they take over, so a chain that migrates to a new drand network (or falls
+// back to LocalBeacon) does not need to fork its election code.
+type BeaconNetworks []BeaconNetwork
+
+// BeaconNetwork pairs a beacon implementation with the first round at which
+// it should be consulted.
+type BeaconNetwork struct {
+	StartRound uint64
+	Beacon     BeaconAPI
+}
+
+// For returns the beacon responsible for the given round: the entry with the
+// highest StartRound that is still <= round.
+func (n BeaconNetworks) For(round uint64) BeaconAPI {
+	sorted := append(BeaconNetworks{}, n...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].StartRound < sorted[j].StartRound })
+
+	var current BeaconAPI
+	for _, net := range sorted {
+		if net.StartRound > round {
+			break
+		}
+		current = net.Beacon
+	}
+	return current
+}
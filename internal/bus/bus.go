@@ -0,0 +1,131 @@
+// Package bus wraps asaskevich/EventBus with a fixed set of typed topics so
+// that the growing number of subsystems in network.Node (gossip, consensus,
+// the beacon, the blockchain, the mempool) can talk to each other without
+// holding direct references to one another. Node.HandleGossip used to reach
+// straight into IndexManager, Blockchain and consensus state; publishing
+// instead lets each subsystem subscribe for what it cares about from its own
+// constructor.
+package bus
+
+import (
+	"github.com/asaskevich/EventBus"
+	"github.com/bleasey/bdns/internal/beacon"
+	"github.com/bleasey/bdns/internal/blockchain"
+)
+
+// Topic names every subscriber and publisher in the codebase should use
+// instead of hand-writing strings.
+const (
+	TopicIncomingTx          = "network:incoming_tx"
+	TopicIncomingBlock       = "network:incoming_block"
+	TopicIncomingRandomShare = "network:incoming_random_share"
+	TopicNewBeaconEntry      = "beacon:new_entry"
+	TopicNewEpoch            = "consensus:new_epoch"
+	TopicSlotLeaderElected   = "consensus:slot_leader_elected"
+	TopicHeadChanged         = "blockchain:head_changed"
+)
+
+// SlotLeaderElectedEvent is the payload published on TopicSlotLeaderElected.
+type SlotLeaderElectedEvent struct {
+	Epoch  int64
+	Leader []byte
+}
+
+// Bus is a thin, typed facade over EventBus.Bus. Each Publish/Subscribe pair
+// below pins down the payload type for one topic so a typo in an event name
+// or payload shape fails at compile time instead of silently never firing.
+type Bus struct {
+	inner EventBus.Bus
+}
+
+// New constructs an empty Bus.
+func New() *Bus {
+	return &Bus{inner: EventBus.New()}
+}
+
+// PublishIncomingTx announces a transaction received over gossip, before it
+// has been validated or added to the pool.
+func (b *Bus) PublishIncomingTx(tx *blockchain.Transaction) {
+	b.inner.Publish(TopicIncomingTx, tx)
+}
+
+// SubscribeIncomingTx registers fn to run for every TopicIncomingTx event.
+func (b *Bus) SubscribeIncomingTx(fn func(tx *blockchain.Transaction)) error {
+	return b.inner.SubscribeAsync(TopicIncomingTx, fn, false)
+}
+
+// PublishIncomingBlock announces a block received over gossip, before it has
+// been validated or appended to the chain.
+func (b *Bus) PublishIncomingBlock(block *blockchain.Block) {
+	b.inner.Publish(TopicIncomingBlock, block)
+}
+
+// SubscribeIncomingBlock registers fn to run for every TopicIncomingBlock
+// event.
+func (b *Bus) SubscribeIncomingBlock(fn func(block *blockchain.Block)) error {
+	return b.inner.SubscribeAsync(TopicIncomingBlock, fn, false)
+}
+
+// IncomingRandomShareEvent is the payload published on
+// TopicIncomingRandomShare.
+type IncomingRandomShareEvent struct {
+	Epoch       int64
+	Sender      string
+	SecretValue int
+	RandomValue int
+}
+
+// PublishIncomingRandomShare announces a commit-reveal secret share received
+// over gossip, for the LocalBeacon fallback.
+func (b *Bus) PublishIncomingRandomShare(event IncomingRandomShareEvent) {
+	b.inner.Publish(TopicIncomingRandomShare, event)
+}
+
+// SubscribeIncomingRandomShare registers fn to run for every
+// TopicIncomingRandomShare event.
+func (b *Bus) SubscribeIncomingRandomShare(fn func(event IncomingRandomShareEvent)) error {
+	return b.inner.SubscribeAsync(TopicIncomingRandomShare, fn, false)
+}
+
+// PublishNewBeaconEntry announces a newly observed beacon round.
+func (b *Bus) PublishNewBeaconEntry(entry beacon.BeaconEntry) {
+	b.inner.Publish(TopicNewBeaconEntry, entry)
+}
+
+// SubscribeNewBeaconEntry registers fn to run for every TopicNewBeaconEntry
+// event.
+func (b *Bus) SubscribeNewBeaconEntry(fn func(entry beacon.BeaconEntry)) error {
+	return b.inner.SubscribeAsync(TopicNewBeaconEntry, fn, false)
+}
+
+// PublishNewEpoch announces that the node has rolled over into a new epoch.
+func (b *Bus) PublishNewEpoch(epoch int64) {
+	b.inner.Publish(TopicNewEpoch, epoch)
+}
+
+// SubscribeNewEpoch registers fn to run for every TopicNewEpoch event.
+func (b *Bus) SubscribeNewEpoch(fn func(epoch int64)) error {
+	return b.inner.SubscribeAsync(TopicNewEpoch, fn, false)
+}
+
+// PublishSlotLeaderElected announces the outcome of an epoch's
+// slot-leader election.
+func (b *Bus) PublishSlotLeaderElected(epoch int64, leader []byte) {
+	b.inner.Publish(TopicSlotLeaderElected, SlotLeaderElectedEvent{Epoch: epoch, Leader: leader})
+}
+
+// SubscribeSlotLeaderElected registers fn to run for every
+// TopicSlotLeaderElected event.
+func (b *Bus) SubscribeSlotLeaderElected(fn func(event SlotLeaderElectedEvent)) error {
+	return b.inner.SubscribeAsync(TopicSlotLeaderElected, fn, false)
+}
+
+// PublishHeadChanged announces that the local chain's head block changed.
+func (b *Bus) PublishHeadChanged(head *blockchain.Block) {
+	b.inner.Publish(TopicHeadChanged, head)
+}
+
+// SubscribeHeadChanged registers fn to run for every TopicHeadChanged event.
+func (b *Bus) SubscribeHeadChanged(fn func(head *blockchain.Block)) error {
+	return b.inner.SubscribeAsync(TopicHeadChanged, fn, false)
+}
@@ -1,38 +1,59 @@
 package network
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"encoding/hex"
 	"fmt"
 	"log"
+	"math/big"
 	"math/rand"
 	"sync"
+	"time"
 
+	"github.com/bleasey/bdns/internal/beacon"
 	"github.com/bleasey/bdns/internal/blockchain"
+	"github.com/bleasey/bdns/internal/bus"
 	"github.com/bleasey/bdns/internal/index"
 	"github.com/bleasey/bdns/internal/consensus"
+	blocksync "github.com/bleasey/bdns/internal/network/sync"
+	"github.com/bleasey/bdns/internal/rpc"
+	"github.com/bleasey/bdns/blockchain/merkle"
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
 )
 
 // Node represents a blockchain peer
 type Node struct {
-	Address         string
-	Port            int
-	Config          NodeConfig
-	P2PNetwork      *P2PNetwork
-	KeyPair         *blockchain.KeyPair
-	RegistryKeys    [][]byte
-	SlotLeaders     map[int64][]byte // epoch to slot leader
-	SlotMutex       sync.Mutex
-	TransactionPool map[int]*blockchain.Transaction
-	TxMutex         sync.Mutex
-	IndexManager    *index.IndexManager
-	Blockchain      *blockchain.Blockchain
-	BcMutex         sync.Mutex
-	RandomNumber    []byte
-    RandomMutex     sync.Mutex 
-	EpochRandoms    map[int64]map[string]consensus.SecretValues
+	Address           string
+	Port              int
+	Config            NodeConfig
+	P2PNetwork        *P2PNetwork
+	KeyPair           *blockchain.KeyPair
+	VRFPublicKey      []byte // BLS VRF key used for election proofs; distinct from KeyPair, which signs transactions
+	VRFPrivateKey     []byte
+	RegistryKeys      [][]byte
+	RegistryVRFMutex  sync.Mutex
+	RegistryVRFKeys   map[string][]byte // hex(chain identity pubkey) -> that registry's VRF public key, learned via MsgVRFKeyAnnouncement
+	RegistryWeights   map[string]int64  // hex(chain identity pubkey) -> stake weight; registries missing an entry default to weight 1
+	SlotLeaders       map[int64][]byte  // epoch to slot leader; superseded by per-block ElectionProof, kept for LocalBeacon-only test setups
+	SlotMutex         sync.Mutex
+	TransactionPool   map[int]*blockchain.Transaction
+	TxMutex           sync.Mutex
+	IndexManager      *index.IndexManager
+	Blockchain        *blockchain.Blockchain
+	BcMutex           sync.Mutex
+	RandomNumber      []byte
+	RandomMutex       sync.Mutex
+	EpochRandoms      map[int64]map[string]consensus.SecretValues
+	Beacon            beacon.BeaconAPI // source of epoch seeds; defaults to the commit-reveal LocalBeacon
+	Bus               *bus.Bus         // decouples gossip from consensus/mempool/blockchain subscribers
+	SyncManager       *blocksync.SyncManager
+	AnchorMutex       sync.Mutex
+	AnchorRecords     map[string]*anchorRecord // block hash (hex) -> anchor verification state
+	PendingAnchorTxID string                   // anchor txid submitted for the current head but not yet included in a block header; see NextBlockAnchorTxID
 }
 
 // Node Config
@@ -40,6 +61,9 @@ type NodeConfig struct {
 	InitialTimestamp int64
 	EpochInterval    int64
 	Seed             float64
+	BeaconChainInfo  *beacon.BeaconChainInfo // set by ConfigureBeacon, which also switches Beacon to this drand chain; setting it directly only changes RoundForEpoch
+	AnchorProvider   rpc.ChainProvider       // optional: external chain to anchor each block's TxRoot to
+	AnchorGracePeriod time.Duration          // how long an unverified anchor is still tolerated before refusing to build on the block
 }
 
 type RandomNumberMsg struct {
@@ -56,23 +80,534 @@ func NewNode(ctx context.Context, addr string, topicName string) (*Node, error)
 		return nil, err
 	}
 
+	vrfPrivateKey, vrfPublicKey, err := beacon.GenerateVRFKeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("generating VRF key pair: %w", err)
+	}
+
 	node := &Node{
 		Address:         p2p.Host.Addrs()[0].String(),
 		P2PNetwork:      p2p,
 		KeyPair:         blockchain.NewKeyPair(),
+		VRFPublicKey:    vrfPublicKey,
+		VRFPrivateKey:   vrfPrivateKey,
+		RegistryVRFKeys: make(map[string][]byte),
+		RegistryWeights: make(map[string]int64),
 		SlotLeaders:     make(map[int64][]byte),
 		TransactionPool: make(map[int]*blockchain.Transaction),
 		IndexManager:    index.NewIndexManager(),
 		Blockchain:      nil,
 		EpochRandoms:    make(map[int64]map[string]consensus.SecretValues),
+		Beacon:          beacon.NewLocalBeacon(),
+		Bus:             bus.New(),
+		AnchorRecords:   make(map[string]*anchorRecord),
 	}
+	node.RegistryVRFKeys[hex.EncodeToString(node.KeyPair.PublicKey)] = vrfPublicKey
+
+	node.registerSubscribers()
+	node.SyncManager = blocksync.NewSyncManager(ctx, p2p.Host, node.hasBlock, node.getBlock, node.headInfo, node.applyBlockAfterMerkleCheck)
+	p2p.Host.Network().Notify(node.syncNotifiee())
 
 	go node.ListenForDirectMessages()
 	go node.P2PNetwork.ListenForGossip()
 	go node.HandleGossip()
+	go node.BroadcastVRFKey()
 	return node, nil
 }
 
+// hasBlock, getBlock and headInfo give SyncManager read access to the local
+// chain without depending on the blockchain package's full API;
+// applyBlockAfterMerkleCheck reuses AddBlock so synced blocks go through the same validation as gossiped
+// ones.
+func (n *Node) hasBlock(hash []byte) bool {
+	_, err := n.getBlock(hash)
+	return err == nil
+}
+
+func (n *Node) getBlock(hash []byte) (*blockchain.Block, error) {
+	n.BcMutex.Lock()
+	defer n.BcMutex.Unlock()
+
+	if n.Blockchain == nil {
+		return nil, fmt.Errorf("sync: node has no blockchain yet")
+	}
+	for _, block := range n.Blockchain.Blocks {
+		if bytes.Equal(block.Hash, hash) {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("sync: unknown block %x", hash)
+}
+
+func (n *Node) headInfo() ([]byte, int64) {
+	n.BcMutex.Lock()
+	defer n.BcMutex.Unlock()
+
+	if n.Blockchain == nil || len(n.Blockchain.Blocks) == 0 {
+		return nil, 0
+	}
+	tip := n.Blockchain.Blocks[len(n.Blockchain.Blocks)-1]
+	return tip.Hash, int64(len(n.Blockchain.Blocks)) - 1
+}
+
+// applyBlockAfterMerkleCheck validates a block's TxRoot before handing it to
+// AddBlock, so SyncManager (and gossip, via IngestGossipedBlock) never
+// splices in a block whose declared transactions don't match what it
+// actually committed to.
+func (n *Node) applyBlockAfterMerkleCheck(block *blockchain.Block) error {
+	if err := n.validateTxRoot(block); err != nil {
+		return err
+	}
+	if err := n.verifyBlockElectionProof(context.Background(), block); err != nil {
+		return err
+	}
+	n.trackBlockAnchor(block)
+	return n.AddBlock(block)
+}
+
+// verifyBlockElectionProof checks that block carries an ElectionProof won by
+// its declared producer, so a block can't be spliced into the chain on the
+// strength of gossip/sync delivery alone without ever having its slot-leader
+// claim checked.
+func (n *Node) verifyBlockElectionProof(ctx context.Context, block *blockchain.Block) error {
+	if block.ElectionProof == nil {
+		return fmt.Errorf("block %x: missing election proof", block.Hash)
+	}
+
+	won, err := n.VerifyElectionProof(ctx, block.ProducerKey, block.ElectionProof)
+	if err != nil {
+		return fmt.Errorf("block %x: verifying election proof: %w", block.Hash, err)
+	}
+	if !won {
+		return fmt.Errorf("block %x: election proof does not win slot leadership for epoch %d", block.Hash, block.ElectionProof.Epoch)
+	}
+	return nil
+}
+
+// trackBlockAnchor records an anchor record for block whenever anchoring is
+// configured, even if block does not yet carry an AnchorTxID: CanBuildOn's
+// grace period is measured from this record's FirstSeen, so a block that
+// never gets anchored still has its clock start here rather than never
+// existing (and so never being refused). It is a no-op when AnchorProvider
+// isn't configured, or for a block already being tracked (e.g. re-applied
+// via both gossip and sync) so it doesn't spawn a second poller for it.
+func (n *Node) trackBlockAnchor(block *blockchain.Block) {
+	if n.Config.AnchorProvider == nil {
+		return
+	}
+
+	key := hex.EncodeToString(block.Hash)
+	n.AnchorMutex.Lock()
+	_, exists := n.AnchorRecords[key]
+	if !exists {
+		n.AnchorRecords[key] = &anchorRecord{TxID: block.AnchorTxID, FirstSeen: time.Now()}
+	}
+	n.AnchorMutex.Unlock()
+
+	if !exists && block.AnchorTxID != "" {
+		go n.verifyBlockAnchor(context.Background(), block.Hash, block.AnchorTxID, block.TxRoot)
+	}
+}
+
+// validateTxRoot recomputes the Merkle root over block's transactions and
+// checks it against the TxRoot the block declares.
+func (n *Node) validateTxRoot(block *blockchain.Block) error {
+	leaves, err := txLeaves(block)
+	if err != nil {
+		return err
+	}
+
+	root := merkle.BuildTree(leaves).Root()
+	if !bytes.Equal(root, block.TxRoot) {
+		return fmt.Errorf("block %x: TxRoot mismatch: declared %x, computed %x", block.Hash, block.TxRoot, root)
+	}
+	return nil
+}
+
+// BuildInclusionProof returns block's hash and the Merkle proof that the
+// transaction at txIndex is committed by it, so a light client can verify a
+// single DNS record without fetching the whole block. DNSRequestHandler
+// attaches these to BDNSResponse so the requester can check them with
+// merkle.VerifyProof instead of trusting the responder outright.
+func (n *Node) BuildInclusionProof(block *blockchain.Block, txIndex int) (blockHash []byte, proof [][]byte, err error) {
+	leaves, err := txLeaves(block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proof, err = merkle.BuildTree(leaves).Proof(txIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	return block.Hash, proof, nil
+}
+
+func txLeaves(block *blockchain.Block) ([][]byte, error) {
+	leaves := make([][]byte, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		serialized, err := json.Marshal(tx)
+		if err != nil {
+			return nil, fmt.Errorf("serializing transaction %d: %w", i, err)
+		}
+		leaves[i] = serialized
+	}
+	return leaves, nil
+}
+
+// anchorRecord tracks the verification state of one block's external-chain
+// anchor.
+type anchorRecord struct {
+	TxID      string
+	FirstSeen time.Time
+	Verified  bool
+}
+
+// SubmitBlockAnchor publishes block's TxRoot to the configured
+// AnchorProvider and records the resulting txid for verification, so it can
+// be carried in the next block header. It is a no-op returning "" when no
+// AnchorProvider is configured.
+func (n *Node) SubmitBlockAnchor(ctx context.Context, block *blockchain.Block) (string, error) {
+	if n.Config.AnchorProvider == nil {
+		return "", nil
+	}
+
+	txid, err := n.Config.AnchorProvider.SubmitAnchor(ctx, block.TxRoot)
+	if err != nil {
+		return "", fmt.Errorf("anchoring block %x: %w", block.Hash, err)
+	}
+
+	n.AnchorMutex.Lock()
+	n.AnchorRecords[hex.EncodeToString(block.Hash)] = &anchorRecord{TxID: txid, FirstSeen: time.Now()}
+	n.AnchorMutex.Unlock()
+
+	go n.verifyBlockAnchor(ctx, block.Hash, txid, block.TxRoot)
+
+	return txid, nil
+}
+
+// anchorVerifyInterval and anchorVerifyMaxAttempts bound verifyBlockAnchor's
+// poll loop to roughly five minutes of wall-clock time, so a bad txid or an
+// unreachable external chain leaks neither the goroutine nor the ticker
+// forever.
+const (
+	anchorVerifyInterval    = 10 * time.Second
+	anchorVerifyMaxAttempts = 30
+)
+
+// verifyBlockAnchor polls AnchorProvider.VerifyAnchor until it confirms the
+// anchor, marking the block's record verified so CanBuildOn stops refusing
+// it. It gives up after anchorVerifyMaxAttempts, or immediately if ctx is
+// canceled, rather than polling forever.
+func (n *Node) verifyBlockAnchor(ctx context.Context, blockHash []byte, txid string, digest []byte) {
+	key := hex.EncodeToString(blockHash)
+	ticker := time.NewTicker(anchorVerifyInterval)
+	defer ticker.Stop()
+
+	for attempt := 0; attempt < anchorVerifyMaxAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := n.Config.AnchorProvider.VerifyAnchor(ctx, txid, digest)
+		if err != nil {
+			log.Println("anchor: verification failed for block", key, ":", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		n.AnchorMutex.Lock()
+		if record, exists := n.AnchorRecords[key]; exists {
+			record.Verified = true
+		}
+		n.AnchorMutex.Unlock()
+		return
+	}
+	log.Println("anchor: giving up verifying block", key, "after", anchorVerifyMaxAttempts, "attempts")
+}
+
+// CanBuildOn reports whether a slot leader may build the next block on top
+// of block: true when anchoring is disabled, the block has no anchor record,
+// the anchor has verified, or the grace period since the record's FirstSeen
+// hasn't elapsed yet. Once the grace period passes without verification, a
+// tracked block is refused so the
+// chain doesn't keep extending an unanchored (and so unauditable) history.
+func (n *Node) CanBuildOn(block *blockchain.Block) bool {
+	if n.Config.AnchorProvider == nil {
+		return true
+	}
+
+	n.AnchorMutex.Lock()
+	record, exists := n.AnchorRecords[hex.EncodeToString(block.Hash)]
+	n.AnchorMutex.Unlock()
+	if !exists || record.Verified {
+		return true
+	}
+
+	return time.Since(record.FirstSeen) < n.Config.AnchorGracePeriod
+}
+
+// hasTrackedAnchor reports whether block already has an anchor record, so
+// callers don't submit another anchor for it. The record may come from
+// SubmitBlockAnchor or from trackBlockAnchor, which records every applied
+// block even when it carries no AnchorTxID, so a true result does not by
+// itself mean an anchor was submitted.
+func (n *Node) hasTrackedAnchor(blockHash []byte) bool {
+	n.AnchorMutex.Lock()
+	defer n.AnchorMutex.Unlock()
+	_, exists := n.AnchorRecords[hex.EncodeToString(blockHash)]
+	return exists
+}
+
+// NextBlockAnchorTxID returns the anchor txid (if any) submitted for the
+// current head but not yet included in a block header, clearing it so it is
+// only consumed once. Block-production code should call this when building
+// the next block's header, rather than stamping a txid onto an already
+// committed head.
+func (n *Node) NextBlockAnchorTxID() string {
+	n.AnchorMutex.Lock()
+	defer n.AnchorMutex.Unlock()
+	txid := n.PendingAnchorTxID
+	n.PendingAnchorTxID = ""
+	return txid
+}
+
+// syncNotifiee exchanges a HelloMessage with every peer as soon as its
+// libp2p connection opens, so SyncManager's periodic reconciliation has a
+// head to compare against without waiting for gossip.
+func (n *Node) syncNotifiee() *network.NotifyBundle {
+	return &network.NotifyBundle{
+		ConnectedF: func(_ network.Network, conn network.Conn) {
+			go n.SyncManager.HandleNewConnection(context.Background(), conn.RemotePeer())
+		},
+	}
+}
+
+// registerSubscribers wires each subsystem's gossip handler up as a Bus
+// subscriber. In a full checkout every subsystem (IndexManager, Blockchain,
+// the mempool) would register its own subscriptions from its constructor;
+// here Node acts as the composition root for all of them.
+func (n *Node) registerSubscribers() {
+	n.Bus.SubscribeIncomingTx(func(tx *blockchain.Transaction) {
+		n.AddTransaction(tx)
+	})
+	n.Bus.SubscribeIncomingBlock(func(block *blockchain.Block) {
+		log.Println("Observed incoming block", hex.EncodeToString(block.Hash), "via bus")
+	})
+	n.Bus.SubscribeIncomingRandomShare(func(event bus.IncomingRandomShareEvent) {
+		n.RandomNumberHandler(event.Epoch, event.Sender, event.SecretValue, event.RandomValue)
+	})
+}
+
+// ConfigureBeacon switches the node from the default LocalBeacon to a
+// DrandBeacon once BeaconChainInfo is known, typically right after the
+// node's Config has been populated from genesis/bootstrap data.
+func (n *Node) ConfigureBeacon(ctx context.Context, info beacon.BeaconChainInfo) {
+	n.Config.BeaconChainInfo = &info
+	drandBeacon := beacon.NewDrandBeacon(ctx, info, "https://api.drand.sh")
+	n.Beacon = drandBeacon
+
+	go func() {
+		for entry := range drandBeacon.Entries {
+			n.Bus.PublishNewBeaconEntry(entry)
+		}
+	}()
+}
+
+// RoundForEpoch maps an epoch to the beacon round that should seed its
+// election, based on the configured BeaconChainInfo (or, absent one, treats
+// the epoch number itself as the round for LocalBeacon).
+func (n *Node) RoundForEpoch(epoch int64) uint64 {
+	if n.Config.BeaconChainInfo == nil {
+		return uint64(epoch)
+	}
+	return n.Config.BeaconChainInfo.RoundAt(epoch, n.Config.EpochInterval, n.Config.InitialTimestamp)
+}
+
+// beaconEntryForEpoch fetches the beacon entry that seeds epoch's
+// slot-leader election and verifies it against the previous round's entry
+// when that entry can be fetched; for round 0, or when the previous entry
+// cannot be fetched, the entry is returned without that check. It replaces the raw EpochRandoms map as the
+// source of epoch randomness: ProduceElectionProof and VerifyElectionProof
+// both draw their election randomness from the entry this returns.
+func (n *Node) beaconEntryForEpoch(ctx context.Context, epoch int64) (beacon.BeaconEntry, error) {
+	round := n.RoundForEpoch(epoch)
+	cur, err := n.Beacon.Entry(ctx, round)
+	if err != nil {
+		return beacon.BeaconEntry{}, fmt.Errorf("epoch %d: fetching beacon round %d: %w", epoch, round, err)
+	}
+
+	if round > 0 {
+		prev, err := n.Beacon.Entry(ctx, round-1)
+		if err == nil {
+			if err := n.Beacon.VerifyEntry(prev, cur); err != nil {
+				return beacon.BeaconEntry{}, fmt.Errorf("epoch %d: %w", epoch, err)
+			}
+		}
+	}
+
+	return cur, nil
+}
+
+// ProduceElectionProof computes this node's VRF election proof for epoch: it
+// fetches the epoch's beacon entry, draws election randomness from it with
+// the ElectionProofProduction domain tag, and signs that randomness with the
+// node's VRF key pair. It returns (nil, nil) when the resulting VRF output
+// does not clear this node's eligibility threshold — producing a proof only
+// when it actually decides the node, so a losing node never emits one.
+func (n *Node) ProduceElectionProof(ctx context.Context, epoch int64) (*beacon.ElectionProof, error) {
+	entry, err := n.beaconEntryForEpoch(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	randomness := beacon.DrawRandomness(entry, beacon.ElectionProofProduction, epoch, n.VRFPublicKey)
+	proof, output, err := beacon.VRFProve(n.VRFPrivateKey, randomness)
+	if err != nil {
+		return nil, fmt.Errorf("epoch %d: producing election proof: %w", epoch, err)
+	}
+
+	if !n.isElectionWinner(n.KeyPair.PublicKey, output) {
+		return nil, nil
+	}
+
+	if head := n.currentHead(); head != nil {
+		if !n.CanBuildOn(head) {
+			return nil, fmt.Errorf("epoch %d: refusing to build on block %x: anchor unverified past grace period", epoch, head.Hash)
+		}
+		if head.AnchorTxID == "" && !n.hasTrackedAnchor(head.Hash) {
+			go func() {
+				txid, err := n.SubmitBlockAnchor(context.Background(), head)
+				if err != nil {
+					log.Println("anchor: submitting anchor for block", hex.EncodeToString(head.Hash), "failed:", err)
+					return
+				}
+				n.AnchorMutex.Lock()
+				n.PendingAnchorTxID = txid
+				n.AnchorMutex.Unlock()
+			}()
+		}
+	}
+
+	n.Bus.PublishSlotLeaderElected(epoch, n.KeyPair.PublicKey)
+	return &beacon.ElectionProof{Epoch: epoch, Output: output, Proof: proof}, nil
+}
+
+// currentHead returns the tip of the local chain, or nil if the node has no
+// blockchain yet.
+func (n *Node) currentHead() *blockchain.Block {
+	n.BcMutex.Lock()
+	defer n.BcMutex.Unlock()
+
+	if n.Blockchain == nil || len(n.Blockchain.Blocks) == 0 {
+		return nil
+	}
+	return n.Blockchain.Blocks[len(n.Blockchain.Blocks)-1]
+}
+
+// VerifyElectionProof re-derives the election randomness for proof.Epoch and
+// checks that producerKey's registered VRF key produced proof over it, then
+// checks the resulting VRF output against producerKey's eligibility
+// threshold. Peers call this against the proof carried in an incoming block
+// instead of consulting SlotLeaders.
+func (n *Node) VerifyElectionProof(ctx context.Context, producerKey []byte, proof *beacon.ElectionProof) (bool, error) {
+	vrfKey, ok := n.lookupVRFKey(producerKey)
+	if !ok {
+		return false, fmt.Errorf("no registered VRF key for producer %x", producerKey)
+	}
+
+	entry, err := n.beaconEntryForEpoch(ctx, proof.Epoch)
+	if err != nil {
+		return false, err
+	}
+
+	randomness := beacon.DrawRandomness(entry, beacon.ElectionProofProduction, proof.Epoch, vrfKey)
+	output, ok := beacon.VRFVerify(vrfKey, randomness, proof.Proof)
+	if !ok || !bytes.Equal(output, proof.Output) {
+		return false, nil
+	}
+
+	return n.isElectionWinner(producerKey, output), nil
+}
+
+// VRFKeyAnnouncement lets a registry advertise the BLS VRF public key peers
+// should verify its election proofs against (see RegistryVRFKeys), since a
+// registry's chain identity key is not itself usable as a VRF key.
+type VRFKeyAnnouncement struct {
+	RegistryKey  []byte
+	VRFPublicKey []byte
+}
+
+// BroadcastVRFKey announces this node's VRF public key to its peers.
+// Without this, no peer has anything to populate RegistryVRFKeys with, and
+// VerifyElectionProof can never find a key to check this node's election
+// proofs against.
+func (n *Node) BroadcastVRFKey() {
+	n.P2PNetwork.BroadcastMessage(MsgVRFKeyAnnouncement, VRFKeyAnnouncement{
+		RegistryKey:  n.KeyPair.PublicKey,
+		VRFPublicKey: n.VRFPublicKey,
+	})
+}
+
+func (n *Node) registerVRFKey(registryKey, vrfKey []byte) {
+	n.RegistryVRFMutex.Lock()
+	defer n.RegistryVRFMutex.Unlock()
+	n.RegistryVRFKeys[hex.EncodeToString(registryKey)] = vrfKey
+}
+
+func (n *Node) lookupVRFKey(registryKey []byte) ([]byte, bool) {
+	n.RegistryVRFMutex.Lock()
+	defer n.RegistryVRFMutex.Unlock()
+	vrfKey, ok := n.RegistryVRFKeys[hex.EncodeToString(registryKey)]
+	return vrfKey, ok
+}
+
+// isElectionWinner decides eligibility by comparing H(output) against a
+// threshold sized to producerKey's share of RegistryWeights (defaulting to
+// weight 1 for registries with no explicit weight), so that across many
+// epochs each registry wins leadership in proportion to its stake rather
+// than just its count in RegistryKeys.
+func (n *Node) isElectionWinner(producerKey []byte, output []byte) bool {
+	totalWeight := n.totalRegistryWeight()
+	if totalWeight == 0 {
+		return false
+	}
+	weight := n.registryWeight(producerKey)
+	if weight == 0 {
+		return false
+	}
+
+	threshold := new(big.Int).Mul(maxHash, big.NewInt(weight))
+	threshold.Div(threshold, big.NewInt(totalWeight))
+
+	digest := sha256.Sum256(output)
+	return new(big.Int).SetBytes(digest[:]).Cmp(threshold) < 0
+}
+
+// registryWeight returns key's configured stake weight, defaulting to 1 when
+// RegistryWeights has no explicit entry for it.
+func (n *Node) registryWeight(key []byte) int64 {
+	if weight, ok := n.RegistryWeights[hex.EncodeToString(key)]; ok {
+		return weight
+	}
+	return 1
+}
+
+func (n *Node) totalRegistryWeight() int64 {
+	var total int64
+	for _, key := range n.RegistryKeys {
+		total += n.registryWeight(key)
+	}
+	return total
+}
+
+// maxHash is the largest possible SHA-256 digest, used as the denominator
+// when turning a VRF output into an eligibility probability.
+var maxHash = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
 func (n *Node) GenerateRandomNumber() []byte {
     n.RandomMutex.Lock()
     defer n.RandomMutex.Unlock()
@@ -103,16 +638,27 @@ func (n *Node) HandleGossip() {
 			err := json.Unmarshal(msg.Content, &tx)
 			if err != nil {
 				log.Println("Failed during unmarshalling")
+				continue
 			}
-			n.AddTransaction(&tx)
+			n.Bus.PublishIncomingTx(&tx)
 
 		case MsgBlock:
 			var block blockchain.Block
 			err := json.Unmarshal(msg.Content, &block)
 			if err != nil {
 				log.Println("Failed during unmarshalling")
+				continue
+			}
+			n.Bus.PublishIncomingBlock(&block)
+
+			sender, err := peer.Decode(msg.Sender)
+			if err != nil {
+				log.Println("sync: gossiped block from unparseable peer id:", err)
+				continue
+			}
+			if err := n.SyncManager.IngestGossipedBlock(context.Background(), &block, sender); err != nil {
+				log.Println("sync: failed to ingest gossiped block:", err)
 			}
-			n.AddBlock(&block)
 
 		case MsgRandomNumber:
             var randomMsg RandomNumberMsg
@@ -121,13 +667,20 @@ func (n *Node) HandleGossip() {
                 log.Println("Failed to unmarshal random number message:", err)
                 continue
             }
-            n.RandomNumberHandler(randomMsg.Epoch, hex.EncodeToString(randomMsg.Sender), randomMsg.SecretValue, randomMsg.RandomValue) // Store the received random number
-
-			// case MsgChainRequest:
-			// 	n.Blockchain.SendBlockchain(conn)
+            n.Bus.PublishIncomingRandomShare(bus.IncomingRandomShareEvent{
+                Epoch:       randomMsg.Epoch,
+                Sender:      hex.EncodeToString(randomMsg.Sender),
+                SecretValue: randomMsg.SecretValue,
+                RandomValue: randomMsg.RandomValue,
+            })
 
-			// case MsgChainResponse:
-			// 	n.Blockchain.ReplaceChain(conn, &n.BcMutex)
+		case MsgVRFKeyAnnouncement:
+			var announcement VRFKeyAnnouncement
+			if err := json.Unmarshal(msg.Content, &announcement); err != nil {
+				log.Println("Failed during unmarshalling")
+				continue
+			}
+			n.registerVRFKey(announcement.RegistryKey, announcement.VRFPublicKey)
 		}
 	}
 
@@ -197,12 +750,57 @@ func (n *Node) DNSRequestHandler(req BDNSRequest, reqSender string) {
 			OwnerKey:   tx.OwnerKey,
 			Signature:  tx.Signature,
 		}
+
+		if block, err := n.getBlock(tx.BlockHash); err != nil {
+			log.Println("dns: owning block for", req.DomainName, "not found locally, responding without inclusion proof:", err)
+		} else if blockHash, proof, err := n.BuildInclusionProof(block, tx.TxIndex); err != nil {
+			log.Println("dns: building inclusion proof for", req.DomainName, ":", err)
+		} else {
+			res.BlockHash = blockHash
+			res.TxIndex = tx.TxIndex
+			res.MerkleProof = proof
+		}
+
 		n.P2PNetwork.DirectMessage(DNSResponse, res, reqSender)
 	}
 	fmt.Println("DNS Request received at ", n.Address, " -> ", req.DomainName)
 }
 
+// DNSResponseHandler checks res's Merkle inclusion proof against the TxRoot
+// of the block it claims to come from before trusting its answer, so a
+// compromised or lying responder can't just hand back an unconnected (or
+// unproven) record. Only a response that passes this check is accepted; the
+// IP is never surfaced otherwise.
 func (n *Node) DNSResponseHandler(res BDNSResponse) {
+	if len(res.MerkleProof) == 0 {
+		log.Println("dns: response for", res.DomainName, "has no inclusion proof, rejecting")
+		return
+	}
+
+	block, err := n.getBlock(res.BlockHash)
+	if err != nil {
+		log.Println("dns: cannot verify response for", res.DomainName, ": owning block", hex.EncodeToString(res.BlockHash), "not known locally:", err)
+		return
+	}
+
+	leaf, err := json.Marshal(blockchain.Transaction{
+		Timestamp:  res.Timestamp,
+		DomainName: res.DomainName,
+		IP:         res.IP,
+		TTL:        res.TTL,
+		OwnerKey:   res.OwnerKey,
+		Signature:  res.Signature,
+	})
+	if err != nil {
+		log.Println("dns: re-serializing response for verification:", err)
+		return
+	}
+
+	if !merkle.VerifyProof(block.TxRoot, leaf, res.MerkleProof, res.TxIndex) {
+		log.Println("dns: response for", res.DomainName, "failed Merkle inclusion check, discarding")
+		return
+	}
+
 	fmt.Println("DNS Response received at ", n.Address, " -> ", res.DomainName, " IP:", res.IP)
 }
 
@@ -222,4 +820,8 @@ func (n *Node) RandomNumberHandler(epoch int64, sender string, secretValue int,
 		SecretValue: secretValue,
 		RandomValue: randomValue,
 	}
+
+	if local, ok := n.Beacon.(*beacon.LocalBeacon); ok {
+		local.SubmitSecret(uint64(epoch), sender, n.EpochRandoms[epoch][sender], n.RegistryKeys)
+	}
 }
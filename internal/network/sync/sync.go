@@ -0,0 +1,431 @@
+// Package sync adds an explicit block/range request-response protocol on top
+// of the existing gossip channel. Gossip alone only tells a node about a
+// block the moment it is produced; if the node missed an earlier block (a
+// dropped message, a restart, a newly-joined peer) it previously had no way
+// to catch up, since Node.HandleGossip never implemented chain
+// request/response handling.
+package sync
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/bleasey/bdns/internal/blockchain"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ProtocolID is the libp2p stream protocol SyncManager listens on, named
+// after the existing ad-hoc "/dns-response" direct-message handler.
+const ProtocolID = "/bdns/sync/blocks/1.0.0"
+
+// ReconcileInterval is how often a node checks whether a random peer's head
+// is ahead of its own and, if so, syncs up.
+const ReconcileInterval = 30 * time.Second
+
+// HelloMessage is exchanged once right after a libp2p connection opens so
+// each side learns the other's advertised chain head without waiting for a
+// gossiped block.
+type HelloMessage struct {
+	HeadHash   []byte
+	HeadHeight int64
+}
+
+const (
+	methodGetBlockByHash = "GetBlockByHash"
+	methodGetBlockRange  = "GetBlockRange"
+	methodGetHead        = "GetHead"
+)
+
+type rpcRequest struct {
+	Method string       `json:"method"`
+	Hash   []byte       `json:"hash,omitempty"`
+	From   int64        `json:"from,omitempty"`
+	To     int64        `json:"to,omitempty"`
+	Head   HelloMessage `json:"head,omitempty"` // this node's own head, carried along with a GetHead request so the exchange is bidirectional
+}
+
+type rpcResponse struct {
+	Blocks []*blockchain.Block `json:"blocks,omitempty"`
+	Head   HelloMessage        `json:"head,omitempty"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// SyncManager tracks peer heads and fills in missing ancestors when a
+// gossiped block's parent has not been seen yet. It is deliberately ignorant
+// of Node: it is handed closures over whatever local chain storage the
+// caller already has, the same seam BeaconAPI and ChainProvider use
+// elsewhere in this codebase.
+type SyncManager struct {
+	Host host.Host
+
+	// HasBlock, GetBlock, Head and ApplyBlock give SyncManager just enough
+	// access to the local chain to serve RPCs and splice in fetched
+	// ancestors; the caller is responsible for guarding its own chain state
+	// (e.g. with Node.BcMutex) inside these functions.
+	HasBlock   func(hash []byte) bool
+	GetBlock   func(hash []byte) (*blockchain.Block, error)
+	Head       func() (hash []byte, height int64)
+	ApplyBlock func(block *blockchain.Block) error
+
+	mu    sync.Mutex
+	peers map[peer.ID]HelloMessage
+
+	// applyMu serializes the check-then-apply sequence in IngestGossipedBlock
+	// so a block gossiped in while reconcileOnce is also backfilling can't
+	// both observe HasBlock==false and apply the same block twice.
+	applyMu sync.Mutex
+}
+
+// NewSyncManager registers the sync stream handler on h and starts the
+// periodic reconciliation loop. Callers should also notify SyncManager of
+// new connections via HandleNewConnection so peer heads are known for
+// reconciliation.
+func NewSyncManager(ctx context.Context, h host.Host, hasBlock func([]byte) bool, getBlock func([]byte) (*blockchain.Block, error), head func() ([]byte, int64), applyBlock func(*blockchain.Block) error) *SyncManager {
+	sm := &SyncManager{
+		Host:       h,
+		HasBlock:   hasBlock,
+		GetBlock:   getBlock,
+		Head:       head,
+		ApplyBlock: applyBlock,
+		peers:      make(map[peer.ID]HelloMessage),
+	}
+	h.SetStreamHandler(ProtocolID, sm.handleStream)
+	go sm.reconcileLoop(ctx)
+	return sm
+}
+
+// HandleNewConnection exchanges a HelloMessage with a newly connected peer
+// and records its advertised head for later reconciliation. Call this from a
+// libp2p network.Notifiee's Connected callback.
+func (sm *SyncManager) HandleNewConnection(ctx context.Context, p peer.ID) {
+	hash, height := sm.Head()
+	s, err := sm.Host.NewStream(ctx, p, ProtocolID)
+	if err != nil {
+		log.Println("sync: hello exchange failed:", err)
+		return
+	}
+	defer s.Close()
+
+	req := rpcRequest{Method: methodGetHead, Head: HelloMessage{HeadHash: hash, HeadHeight: height}}
+	if err := writeJSON(s, req); err != nil {
+		log.Println("sync: hello exchange failed:", err)
+		return
+	}
+	var resp rpcResponse
+	if err := readJSON(s, &resp); err != nil {
+		log.Println("sync: hello exchange failed:", err)
+		return
+	}
+
+	sm.mu.Lock()
+	sm.peers[p] = resp.Head
+	sm.mu.Unlock()
+}
+
+func (sm *SyncManager) handleStream(s network.Stream) {
+	defer s.Close()
+
+	var req rpcRequest
+	if err := readJSON(s, &req); err != nil {
+		log.Println("sync: failed to decode request:", err)
+		return
+	}
+
+	var resp rpcResponse
+	switch req.Method {
+	case methodGetBlockByHash:
+		block, err := sm.GetBlock(req.Hash)
+		if err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Blocks = []*blockchain.Block{block}
+		}
+
+	case methodGetBlockRange:
+		blocks, err := sm.collectRange(req.From, req.To)
+		if err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Blocks = blocks
+		}
+
+	case methodGetHead:
+		sm.mu.Lock()
+		sm.peers[s.Conn().RemotePeer()] = req.Head
+		sm.mu.Unlock()
+
+		hash, height := sm.Head()
+		resp.Head = HelloMessage{HeadHash: hash, HeadHeight: height}
+
+	default:
+		resp.Error = fmt.Sprintf("sync: unknown method %q", req.Method)
+	}
+
+	if err := writeJSON(s, resp); err != nil {
+		log.Println("sync: failed to write response:", err)
+	}
+}
+
+// collectRange walks the local chain from the block at height `to` back to
+// height `from` via PrevBlockHash links, since Blockchain does not expose a
+// height index. `to` is clamped to the local head height: a peer asking for
+// a range past our head would otherwise have the head mislabeled as height
+// `to` and walk past genesis chasing a `height` that never arrives.
+func (sm *SyncManager) collectRange(from, to int64) ([]*blockchain.Block, error) {
+	hash, height := sm.Head()
+	if to > height {
+		to = height
+	}
+	if to < height {
+		block, err := sm.GetBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		for height > to {
+			block, err = sm.GetBlock(block.PrevBlockHash)
+			if err != nil {
+				return nil, err
+			}
+			height--
+		}
+		hash = block.Hash
+	}
+
+	var blocks []*blockchain.Block
+	cur, err := sm.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	for height := to; height >= from; height-- {
+		blocks = append([]*blockchain.Block{cur}, blocks...)
+		if height == from {
+			break
+		}
+		cur, err = sm.GetBlock(cur.PrevBlockHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+// maxAncestorHops bounds how many ancestors IngestGossipedBlock will chase
+// one at a time before giving up on that approach and falling back to a
+// single GetBlockRange call: without a cap, a faulty or malicious sender
+// could dangle a block whose parent is never reachable and drive unbounded
+// per-ancestor round trips.
+const maxAncestorHops = 8
+
+// IngestGossipedBlock applies a block received over gossip. If its parent is
+// unknown it walks backwards, requesting ancestors from sender one at a
+// time, until it reaches a block already present locally or maxAncestorHops
+// is exceeded, in which case it falls back to backfillByRange. The whole
+// check-then-apply sequence runs under applyMu so a concurrent
+// reconcileOnce (which also calls this method) can't observe the same
+// missing block and apply it twice.
+func (sm *SyncManager) IngestGossipedBlock(ctx context.Context, block *blockchain.Block, sender peer.ID) error {
+	sm.applyMu.Lock()
+	defer sm.applyMu.Unlock()
+
+	if sm.HasBlock(block.Hash) {
+		return nil
+	}
+
+	missing := []*blockchain.Block{block}
+	cursor := block
+	for hops := 0; !sm.HasBlock(cursor.PrevBlockHash); hops++ {
+		if hops >= maxAncestorHops {
+			return sm.backfillByRange(ctx, block, sender)
+		}
+
+		ancestor, err := sm.requestBlockByHash(ctx, sender, cursor.PrevBlockHash)
+		if err != nil {
+			return fmt.Errorf("sync: fetching ancestor of block: %w", err)
+		}
+		missing = append(missing, ancestor)
+		cursor = ancestor
+	}
+
+	oldestFirst := make([]*blockchain.Block, len(missing))
+	for i, b := range missing {
+		oldestFirst[len(missing)-1-i] = b
+	}
+	return sm.applyOldestFirst(oldestFirst)
+}
+
+// backfillByRange fetches the whole gap between the local head and block in
+// one GetBlockRange request, instead of chasing ancestors one at a time,
+// when that gap is deeper than maxAncestorHops. It relies on sender's
+// advertised head (recorded from the Hello exchange) to bound the range.
+func (sm *SyncManager) backfillByRange(ctx context.Context, block *blockchain.Block, sender peer.ID) error {
+	_, localHeight := sm.Head()
+
+	sm.mu.Lock()
+	peerHead, known := sm.peers[sender]
+	sm.mu.Unlock()
+	if !known || peerHead.HeadHeight <= localHeight {
+		return fmt.Errorf("sync: block %x is more than %d ancestors ahead of local head, and sender's advertised head doesn't explain the gap", block.Hash, maxAncestorHops)
+	}
+
+	blocks, err := sm.requestBlockRange(ctx, sender, localHeight+1, peerHead.HeadHeight)
+	if err != nil {
+		return fmt.Errorf("sync: ranged backfill: %w", err)
+	}
+	return sm.applyOldestFirst(append(blocks, block))
+}
+
+// applyOldestFirst applies blocks, which must already be ordered oldest to
+// newest, skipping any already present locally.
+func (sm *SyncManager) applyOldestFirst(blocks []*blockchain.Block) error {
+	for _, b := range blocks {
+		if sm.HasBlock(b.Hash) {
+			continue
+		}
+		if err := sm.ApplyBlock(b); err != nil {
+			return fmt.Errorf("sync: applying block: %w", err)
+		}
+	}
+	return nil
+}
+
+func (sm *SyncManager) requestBlockByHash(ctx context.Context, p peer.ID, hash []byte) (*blockchain.Block, error) {
+	s, err := sm.Host.NewStream(ctx, p, ProtocolID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	if err := writeJSON(s, rpcRequest{Method: methodGetBlockByHash, Hash: hash}); err != nil {
+		return nil, err
+	}
+
+	var resp rpcResponse
+	if err := readJSON(s, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("sync: peer returned error: %s", resp.Error)
+	}
+	if len(resp.Blocks) == 0 {
+		return nil, fmt.Errorf("sync: peer returned no block for requested hash")
+	}
+	if !bytes.Equal(resp.Blocks[0].Hash, hash) {
+		return nil, fmt.Errorf("sync: peer returned block %x for requested hash %x", resp.Blocks[0].Hash, hash)
+	}
+	return resp.Blocks[0], nil
+}
+
+// requestBlockRange fetches blocks [from, to] (inclusive, oldest first) from
+// p via GetBlockRange.
+func (sm *SyncManager) requestBlockRange(ctx context.Context, p peer.ID, from, to int64) ([]*blockchain.Block, error) {
+	s, err := sm.Host.NewStream(ctx, p, ProtocolID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	if err := writeJSON(s, rpcRequest{Method: methodGetBlockRange, From: from, To: to}); err != nil {
+		return nil, err
+	}
+
+	var resp rpcResponse
+	if err := readJSON(s, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("sync: peer returned error: %s", resp.Error)
+	}
+	return resp.Blocks, nil
+}
+
+// reconcileLoop periodically compares the local head against a random known
+// peer's, fetching and applying the missing range if the local chain is
+// behind.
+func (sm *SyncManager) reconcileLoop(ctx context.Context) {
+	ticker := time.NewTicker(ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sm.reconcileOnce(ctx)
+		}
+	}
+}
+
+func (sm *SyncManager) reconcileOnce(ctx context.Context) {
+	sm.mu.Lock()
+	peers := make([]peer.ID, 0, len(sm.peers))
+	for p := range sm.peers {
+		peers = append(peers, p)
+	}
+	sm.mu.Unlock()
+	if len(peers) == 0 {
+		return
+	}
+	target := peers[rand.Intn(len(peers))]
+
+	_, localHeight := sm.Head()
+	sm.mu.Lock()
+	peerHead := sm.peers[target]
+	sm.mu.Unlock()
+	if peerHead.HeadHeight <= localHeight {
+		return
+	}
+
+	block, err := sm.requestBlockByHash(ctx, target, peerHead.HeadHash)
+	if err != nil {
+		log.Println("sync: reconcile failed:", err)
+		return
+	}
+	if err := sm.IngestGossipedBlock(ctx, block, target); err != nil {
+		log.Println("sync: reconcile failed:", err)
+	}
+}
+
+// writeJSON writes v as a 4-byte big-endian length prefix followed by its
+// JSON encoding, mirroring the length-prefixed framing libp2p streams need
+// since they have no message boundaries of their own.
+func writeJSON(w io.Writer, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(payload)))
+	if _, err := w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(payload)
+	return err
+}
+
+func readJSON(r io.Reader, v interface{}) error {
+	br := bufio.NewReader(r)
+
+	var length [4]byte
+	if _, err := io.ReadFull(br, length[:]); err != nil {
+		return err
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint32(length[:]))
+	if _, err := io.ReadFull(br, payload); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(payload, v)
+}
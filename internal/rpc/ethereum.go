@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// EthereumProvider implements ChainProvider by sending a zero-value
+// transaction whose calldata is the anchored digest to AnchorAddress, and
+// verifying anchors by checking a transaction's calldata on lookup.
+type EthereumProvider struct {
+	Client        *ethclient.Client
+	ChainID       *big.Int
+	AnchorAddress common.Address
+	SignerKey     *ecdsa.PrivateKey
+}
+
+// NewEthereumProvider dials an Ethereum JSON-RPC endpoint and derives the
+// chain ID needed to sign anchor transactions.
+func NewEthereumProvider(ctx context.Context, endpoint string, anchorAddress common.Address, signerKey *ecdsa.PrivateKey) (*EthereumProvider, error) {
+	client, err := ethclient.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("rpc: dialing %s: %w", endpoint, err)
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rpc: fetching chain id: %w", err)
+	}
+
+	return &EthereumProvider{
+		Client:        client,
+		ChainID:       chainID,
+		AnchorAddress: anchorAddress,
+		SignerKey:     signerKey,
+	}, nil
+}
+
+// SubmitAnchor implements ChainProvider.
+func (p *EthereumProvider) SubmitAnchor(ctx context.Context, digest []byte) (string, error) {
+	auth, err := bind.NewKeyedTransactorWithChainID(p.SignerKey, p.ChainID)
+	if err != nil {
+		return "", fmt.Errorf("rpc: building transactor: %w", err)
+	}
+
+	nonce, err := p.Client.PendingNonceAt(ctx, auth.From)
+	if err != nil {
+		return "", fmt.Errorf("rpc: fetching nonce: %w", err)
+	}
+
+	gasPrice, err := p.Client.SuggestGasPrice(ctx)
+	if err != nil {
+		return "", fmt.Errorf("rpc: fetching gas price: %w", err)
+	}
+
+	gasLimit := uint64(21000 + len(digest)*16)
+	tx := types.NewTransaction(nonce, p.AnchorAddress, big.NewInt(0), gasLimit, gasPrice, digest)
+
+	signedTx, err := auth.Signer(auth.From, tx)
+	if err != nil {
+		return "", fmt.Errorf("rpc: signing anchor tx: %w", err)
+	}
+
+	if err := p.Client.SendTransaction(ctx, signedTx); err != nil {
+		return "", fmt.Errorf("rpc: submitting anchor tx: %w", err)
+	}
+
+	return signedTx.Hash().Hex(), nil
+}
+
+// VerifyAnchor implements ChainProvider.
+func (p *EthereumProvider) VerifyAnchor(ctx context.Context, txid string, digest []byte) (bool, error) {
+	tx, isPending, err := p.Client.TransactionByHash(ctx, common.HexToHash(txid))
+	if err != nil {
+		return false, fmt.Errorf("rpc: fetching anchor tx %s: %w", txid, err)
+	}
+	if isPending {
+		return false, nil
+	}
+
+	return bytes.Equal(tx.Data(), digest), nil
+}
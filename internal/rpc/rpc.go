@@ -0,0 +1,22 @@
+// Package rpc anchors BDNS block commitments to an external chain. BDNS's
+// own validator set stays the coordination layer for DNS record writes, but
+// a digest of each block's committed records is also pushed to an L1 so a
+// rewrite of BDNS history would have to also forge evidence on a chain the
+// attacker doesn't control.
+package rpc
+
+import "context"
+
+// ChainProvider submits and checks anchors on one external chain. BDNS talks
+// to it only through this interface so which chain is used (Ethereum today,
+// Filecoin or Solana tomorrow) is a NodeConfig choice, not a code fork.
+type ChainProvider interface {
+	// SubmitAnchor publishes digest (a block's TxRoot) to the external chain
+	// and returns an identifier (e.g. a transaction hash) that later proves
+	// it was published.
+	SubmitAnchor(ctx context.Context, digest []byte) (txid string, err error)
+
+	// VerifyAnchor checks that txid on the external chain actually committed
+	// to digest.
+	VerifyAnchor(ctx context.Context, txid string, digest []byte) (bool, error)
+}